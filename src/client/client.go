@@ -4,21 +4,27 @@ import (
 	"bufio"
 	"bytes"
 	"configuration"
+	"context"
 	"dlog"
 	"encoding/gob"
 	"flag"
 	"fmt"
 	"genericsmrproto"
 	"kvproto"
+	"linchecker"
 	"log"
+	"math"
 	"math/rand"
 	"net"
 	"os"
+	"os/signal"
 	"sort"
 	"state"
 	"sync"
+	"syscall"
 	"time"
 	"util"
+	"workload"
 )
 
 var serverId = flag.Int("id", 0, "Id of server")
@@ -26,6 +32,8 @@ var startRange = flag.Int("sr", 0, "Key range start")
 var sport = flag.Int("sport", 7074, "the port of the server")
 var separate = flag.Bool("sp", false, "each batch contain one opeartion type")
 var algo = flag.String("algo", "epaxos", "algorithm")
+var repeat = flag.Int("repeat", 0, "repeat the benchmark this many times back-to-back against the same connection; overrides benchmark.rounds if > 0")
+var warmup = flag.Duration("warmup", 0, "warmup duration per round whose samples are dropped before aggregation, e.g. 5s")
 
 var INV = uint8(0)
 var RES = uint8(1)
@@ -50,25 +58,179 @@ type Summary struct {
 }
 
 type OperationLog struct {
-	Type uint8
-	Op   kvproto.Operation
-	K    kvproto.Key
-	V    kvproto.Value
-	Ts   int64
+	Type     uint8
+	ClientId int
+	Op       kvproto.Operation
+	K        kvproto.Key
+	V        kvproto.Value
+	Ts       int64
+	TID      int64
+}
+
+// logOp appends a single INV/RES record to the linearizability log,
+// serialized with gob so it can be replayed by the linchecker package.
+// One encoder is kept per log writer so the whole file is a single gob
+// stream that one decoder can read back.
+var logEnc *gob.Encoder
+var logEncWriter *bufio.Writer
+
+func logOp(fileLock *sync.Mutex, fileWriter *bufio.Writer, entry OperationLog) {
+	fileLock.Lock()
+	defer fileLock.Unlock()
+	if logEnc == nil || logEncWriter != fileWriter {
+		logEnc = gob.NewEncoder(fileWriter)
+		logEncWriter = fileWriter
+	}
+	if err := logEnc.Encode(entry); err != nil {
+		log.Printf("Error writing linearizability record: %v\n", err)
+	}
+}
+
+// pendingKeys tracks, per in-flight abd transaction id, the client and
+// keys sent so the response reader can pair each returned value back
+// with its key when logging the RES half of the history.
+type pendingTxn struct {
+	clientId int
+	keys     []kvproto.Key
+}
+
+type pendingKeys struct {
+	sync.Mutex
+	byTID map[int64]pendingTxn
+}
+
+func newPendingKeys() *pendingKeys {
+	return &pendingKeys{byTID: make(map[int64]pendingTxn)}
+}
+
+func (p *pendingKeys) put(tid int64, clientId int, keys []kvproto.Key) {
+	p.Lock()
+	p.byTID[tid] = pendingTxn{clientId, keys}
+	p.Unlock()
+}
+
+func (p *pendingKeys) take(tid int64) pendingTxn {
+	p.Lock()
+	txn := p.byTID[tid]
+	delete(p.byTID, tid)
+	p.Unlock()
+	return txn
+}
+
+// pendingOps tracks, per in-flight epaxos CommandId, the INV record
+// logged for it so the response reader can pair a ProposeReply back to
+// the invocation it actually answers. EPaxos replies can complete out
+// of send order (a conflicting command takes the slow path while later,
+// non-conflicting commands take the fast path), so CommandId -- not
+// channel/file order -- is what makes the pairing correct.
+type pendingOps struct {
+	sync.Mutex
+	byCommandId map[int32]OperationLog
+}
+
+func newPendingOps() *pendingOps {
+	return &pendingOps{byCommandId: make(map[int32]OperationLog)}
+}
+
+func (p *pendingOps) put(commandId int32, entry OperationLog) {
+	p.Lock()
+	p.byCommandId[commandId] = entry
+	p.Unlock()
+}
+
+func (p *pendingOps) take(commandId int32) (OperationLog, bool) {
+	p.Lock()
+	entry, ok := p.byCommandId[commandId]
+	delete(p.byCommandId, commandId)
+	p.Unlock()
+	return entry, ok
 }
 
 func main() {
 	b := config.Benchmark
-	conflicts := b.Conflicts
+	if b.Conflicts > 100 {
+		log.Fatalf("Conflicts percentage must be between 0 and 100.\n")
+	}
+
+	rounds := b.Rounds
+	if *repeat > 0 {
+		rounds = *repeat
+	}
+	if rounds < 1 {
+		rounds = 1
+	}
+
+	outfilelock := &sync.Mutex{}
+	outFileName := "./linearizability.out"
+	f, _ := os.Create(outFileName)
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	//write to linearizabilty out
+	defer w.Flush()
+
+	server, err := net.Dial("tcp", fmt.Sprintf(":%d", *sport))
+	if err != nil {
+		log.Printf("Error connecting to replica %d at %v. Error is: %v \n", *serverId, fmt.Sprintf("127.0.0.1:%d", *sport), err)
+	}
+
+	seed := rand.New(rand.NewSource(int64(*serverId)))
+	keyGen, err := workload.New(b.Distribution, b, *startRange, seed)
+	if err != nil {
+		log.Fatalf("Error building key generator: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	go watchSignals(sigCh, cancel)
+
+	roundResults := make([]RoundResult, 0, rounds)
+	interrupted := false
+	for round := 1; round <= rounds; round++ {
+		result, ok := runRound(ctx, round, rounds, b, server, keyGen, outfilelock, w)
+		roundResults = append(roundResults, result)
+		if !ok {
+			interrupted = true
+			log.Printf("Round %d interrupted; stopping before round %d\n", round, round+1)
+			break
+		}
+	}
+
+	if len(roundResults) > 1 {
+		reportAggregate(roundResults)
+	}
+
+	if !interrupted && b.LinearizabilityCheck {
+		w.Flush()
+		checkLinearizability(outFileName)
+	}
+
+	server.Close()
+}
+
+// RoundResult is one round's latency distribution plus the throughput
+// it achieved, the two figures aggregate reporting combines across
+// rounds.
+type RoundResult struct {
+	Stat       Statistic
+	Throughput float64
+}
+
+// runRound drives a single repetition of the benchmark loop over the
+// already-connected server, resetting the per-round ticker/timer and
+// Summary, and returns this round's (warmup-trimmed) latency stats. The
+// second return value is false when ctx was cancelled mid-round (a
+// caught SIGINT/SIGTERM), in which case the round's results reflect
+// whatever was acknowledged before the drain deadline rather than a
+// full run.
+func runRound(ctx context.Context, round, rounds int, b configuration.Bconfig, server net.Conn,
+	keyGen workload.KeyGenerator, outfilelock *sync.Mutex, w *bufio.Writer) (RoundResult, bool) {
+
 	readRatio := 1 - b.W
 	reqNum := b.Throttle
 	batchSize := config.BatchSize
 	concurrency := b.Concurrency
 
-	if conflicts > 100 {
-		log.Fatalf("Conflicts percentage must be between 0 and 100.\n")
-	}
-
 	//generating keys
 	tsArray := make([]int64, reqNum)
 	ackTsArray := make([]int64, reqNum)
@@ -76,86 +238,47 @@ func main() {
 	readArray := make([]bool, reqNum)
 	kArray := make([]int64, reqNum)
 
-	log.Printf("Zipfan Theta %f\n, ReadRatio: %f, Con: %d", b.ZipfianS, readRatio, concurrency)
-	// log.Printf("Config %v", config)
-
-	// zipGenerator := util.NewZipfianWithItems(int64(b.K), b.ZipfianTheta)
-	// log.Printf("KeySpaace %d", int64(b.K))
-	zipGenerator := util.NewZipfianWithItems(int64(b.K), b.ZipfianTheta)
-	// seed := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
-	// seed := rand.New(rand.NewSource(int64(*serverId)))
-	seed := rand.New(rand.NewSource(int64(*serverId)))
-	for i := 0; i < reqNum; i++ {
-		if b.Distribution == "zipfan" {
-			kArray[i] = zipGenerator.Next(seed)
-			// kArray[i] = 42
-			// kArray[i] = int64(*serverId*1000000 + i)
-			// log.Printf("Key is %d", kArray[i])
-		} else {
-			r := rand.Intn(100)
-			if r < conflicts {
-				kArray[i] = 42
-			} else {
-				//we don't care about the conflict rate send to the same leader
-				kArray[i] = int64(*startRange + 43 + i)
-			}
-		}
-		tsArray[i] = 0
-		ackTsArray[i] = 0
-	}
-
-	log.Printf("Client %d: KeyArray is %v\n", *serverId, kArray)
-
 	for i := 0; i < reqNum; {
 		bNum := min(batchSize, reqNum-i)
-		isRead := false
-		if rand.Float64() < readRatio {
-			isRead = true
-		}
-		if *separate {
-			for j := 0; j < bNum; j++ {
-				readArray[i] = isRead
-				if isRead {
-				}
-				i++
+		isRead := rand.Float64() < readRatio
+		for j := 0; j < bNum; j++ {
+			read := isRead
+			if !*separate {
+				read = rand.Float64() < readRatio
 			}
-		} else {
-			readArray[i] = isRead
+			kArray[i] = keyGen.Next()
+			readArray[i] = read
+			tsArray[i] = 0
+			ackTsArray[i] = 0
 			i++
 		}
 	}
 
-	outfilelock := &sync.Mutex{}
-	outFileName := "./linearizability.out"
-	f, _ := os.Create(outFileName)
-	defer f.Close()
-	w := bufio.NewWriter(f)
-	//write to linearizabilty out
-	defer w.Flush()
-	server, err := net.Dial("tcp", fmt.Sprintf(":%d", *sport))
-	if err != nil {
-		log.Printf("Error connecting to replica %d at %v. Error is: %v \n", *serverId, fmt.Sprintf("127.0.0.1:%d", *sport), err)
-	}
+	log.Printf("Round %d/%d: Client %d KeyArray is %v\n", round, rounds, *serverId, kArray)
+
 	reader := bufio.NewReader(server)
 	writer := bufio.NewWriter(server)
-	// inFlight := make(chan bool, (reqNum/batchSize)+1)
 	totalCount := 0
 	totalLatency := int64(0)
 	var respSummary Summary
 
+	live.reset(round)
+
 	if *algo == "abd" {
 		inFlight := make(chan bool, concurrency)
 		done := make(chan Summary)
-		go abdClient(writer, 0, kArray, readArray, reqNum, inFlight, outfilelock, w)
-		go getAbdResponse(reader, done, inFlight)
+		pending := newPendingKeys()
+		go abdClient(ctx, writer, 0, kArray, readArray, reqNum, inFlight, outfilelock, w, pending)
+		go getAbdResponse(ctx, reader, done, inFlight, outfilelock, w, pending)
 		respSummary = <-done
 		totalCount = respSummary.AckNum
 		totalLatency = respSummary.TotalLat
 	} else { //epaxos
 		inFlight := make(chan int, concurrency)
 		done := make(chan Summary)
-		go epaxosClient(writer, kArray, readArray, reqNum, inFlight, outfilelock, w)
-		go readFastEpaxosResponse(reader, inFlight, done)
+		pending := newPendingOps()
+		go epaxosClient(ctx, writer, kArray, readArray, reqNum, inFlight, outfilelock, w, pending)
+		go readFastEpaxosResponse(ctx, reader, inFlight, done, outfilelock, w, pending)
 		respSummary = <-done
 		totalCount = respSummary.AckNum
 		totalLatency = respSummary.TotalLat
@@ -163,26 +286,114 @@ func main() {
 		respSummary.TotalRead = int64(totalCount)
 	}
 
-	log.Printf("Throughput: %d req/s", totalCount/b.T)
-	log.Printf("Lat per req: %d ms\n", totalLatency/int64(totalCount))
-	log.Printf("Total latency: %d ms\n", totalLatency)
-	log.Printf("Total slow %d\n", respSummary.TotalSlow)
-	log.Printf("Slow rate %f\n", float64(respSummary.TotalSlow)/float64(respSummary.TotalRead))
-	stat := Statistic(respSummary.LatArray[:totalCount])
-	log.Println(stat)
-
-	dlog.Infof("Throughput: %d req/s", totalCount/b.T)
-	dlog.Infof("Lat per req: %d ms\n", totalLatency/int64(totalCount))
-	dlog.Infof("Total latency: %d ms\n", totalLatency)
-	dlog.Infof("Total slow %d\n", respSummary.TotalSlow)
-	dlog.Infof("Slow rate %f\n", float64(respSummary.TotalSlow)/float64(respSummary.TotalRead))
+	interrupted := ctx.Err() != nil
+	if totalCount == 0 {
+		log.Printf("Round %d: interrupted before any request was acknowledged\n", round)
+		return RoundResult{}, !interrupted
+	}
+
+	throughput := float64(totalCount) / float64(b.T)
+
+	log.Printf("Round %d: Throughput: %.2f req/s", round, throughput)
+	log.Printf("Round %d: Lat per req: %d ms\n", round, totalLatency/int64(totalCount))
+	log.Printf("Round %d: Total latency: %d ms\n", round, totalLatency)
+	log.Printf("Round %d: Total slow %d\n", round, respSummary.TotalSlow)
+	log.Printf("Round %d: Slow rate %f\n", round, float64(respSummary.TotalSlow)/float64(respSummary.TotalRead))
+	stat := Statistic(dropWarmup(respSummary.LatArray[:totalCount], *warmup, time.Duration(b.T)*time.Second))
+	log.Printf("Round %d stats: %s\n", round, stat)
+
+	dlog.Infof("Round %d: Throughput: %.2f req/s", round, throughput)
+	dlog.Infof("Round %d: Lat per req: %d ms\n", round, totalLatency/int64(totalCount))
+	dlog.Infof("Round %d: Total latency: %d ms\n", round, totalLatency)
+	dlog.Infof("Round %d: Total slow %d\n", round, respSummary.TotalSlow)
+	dlog.Infof("Round %d: Slow rate %f\n", round, float64(respSummary.TotalSlow)/float64(respSummary.TotalRead))
 	dlog.Info(stat)
 
-	if b.DumpLatency {
-		stat.WriteFile("latency." + fmt.Sprint(*serverId) + ".out")
+	if b.DumpLatency || interrupted {
+		name := fmt.Sprintf("latency.%d.round%d.out", *serverId, round)
+		if interrupted {
+			log.Printf("Round %d: interrupted, writing partial results to %s\n", round, name)
+		}
+		if err := stat.WriteFile(name, round, throughput); err != nil {
+			log.Printf("Error writing latency file %s: %v\n", name, err)
+		}
 	}
 
-	server.Close()
+	return RoundResult{Stat: stat, Throughput: throughput}, !interrupted
+}
+
+// dropWarmup trims the leading share of samples that fall within the
+// opening warmup period. Summary only records latencies, not the wall
+// clock time each sample completed at, so the warmup share of the round
+// is approximated from sample order and the round's total duration.
+func dropWarmup(samples []int64, warmup, roundDuration time.Duration) []int64 {
+	if warmup <= 0 || roundDuration <= 0 || len(samples) == 0 {
+		return samples
+	}
+	frac := float64(warmup) / float64(roundDuration)
+	if frac >= 1 {
+		return samples[:0]
+	}
+	drop := int(frac * float64(len(samples)))
+	if drop >= len(samples) {
+		return samples[:0]
+	}
+	return samples[drop:]
+}
+
+// reportAggregate combines every round's latency samples into a single
+// pooled distribution and summarizes throughput across rounds, so a
+// -repeat run ends with one headline number instead of N separate ones.
+func reportAggregate(results []RoundResult) {
+	var pooled Statistic
+	throughputs := make([]float64, len(results))
+	for i, r := range results {
+		pooled = append(pooled, r.Stat...)
+		throughputs[i] = r.Throughput
+	}
+
+	minT, maxT, sum := throughputs[0], throughputs[0], 0.0
+	for _, t := range throughputs {
+		if t < minT {
+			minT = t
+		}
+		if t > maxT {
+			maxT = t
+		}
+		sum += t
+	}
+	meanT := sum / float64(len(throughputs))
+	var sumSq float64
+	for _, t := range throughputs {
+		d := t - meanT
+		sumSq += d * d
+	}
+	stdT := math.Sqrt(sumSq / float64(len(throughputs)))
+
+	log.Printf("Aggregate over %d rounds: latency %s\n", len(results), pooled)
+	log.Printf("Aggregate throughput: min=%.2f max=%.2f mean=%.2f stddev=%.2f req/s\n", minT, maxT, meanT, stdT)
+	dlog.Infof("Aggregate over %d rounds: latency %s\n", len(results), pooled)
+	dlog.Infof("Aggregate throughput: min=%.2f max=%.2f mean=%.2f stddev=%.2f req/s\n", minT, maxT, meanT, stdT)
+}
+
+// checkLinearizability runs the linchecker against the just-recorded
+// operation log and reports a pass/fail per key.
+func checkLinearizability(outFileName string) {
+	results, err := linchecker.Check(outFileName)
+	if err != nil {
+		log.Printf("Linearizability check failed to run: %v\n", err)
+		return
+	}
+	bad := 0
+	for k, r := range results {
+		if r.Linearizable {
+			log.Printf("Linearizability OK: key %d (%d ops)\n", k, r.Ops)
+		} else {
+			bad++
+			log.Printf("Linearizability VIOLATION: key %d (%d ops): %s\n", k, r.Ops, r.Counterexample)
+		}
+	}
+	log.Printf("Linearizability check: %d/%d keys linearizable\n", len(results)-bad, len(results))
 }
 
 func min(x, y int) int {
@@ -204,6 +415,7 @@ func sendObject(writer *bufio.Writer, object interface{}) {
 }
 
 func abdClient(
+	ctx context.Context,
 	writer *bufio.Writer,
 	clientId int,
 	kArray []int64,
@@ -211,7 +423,8 @@ func abdClient(
 	txnNum int,
 	inFlight chan bool,
 	fileLock *sync.Mutex,
-	fileWriter *bufio.Writer) {
+	fileWriter *bufio.Writer,
+	pending *pendingKeys) {
 	time.Sleep(time.Duration(*serverId) * time.Millisecond)
 	batchSize := config.BatchSize
 	cmd := kvproto.Command{Op: kvproto.PUT, K: 0, Val: 0}
@@ -228,6 +441,9 @@ loop:
 		select {
 		case <-timer.C:
 			break loop
+		case <-ctx.Done():
+			log.Printf("Send loop cancelled at %d/%d\n", i, n)
+			break loop
 		default:
 			//construct transaction
 			bNum := min(batchSize, n-i)
@@ -259,6 +475,16 @@ loop:
 			<-ticker.C
 			txn.Ts = util.MakeTimestamp(0)
 			txn.TID = int64(i)
+
+			keys := make([]kvproto.Key, len(txn.Commands))
+			for j, c := range txn.Commands {
+				keys[j] = c.K
+				logOp(fileLock, fileWriter, OperationLog{
+					Type: INV, ClientId: clientId, Op: c.Op, K: c.K, V: c.Val, Ts: txn.Ts, TID: txn.TID,
+				})
+			}
+			pending.put(txn.TID, clientId, keys)
+
 			sendObject(writer, txn)
 			inFlight <- true
 		}
@@ -266,7 +492,8 @@ loop:
 	log.Printf("Out of loop %d\n", i)
 }
 
-func getAbdResponse(reader *bufio.Reader, done chan Summary, inFlight chan bool) {
+func getAbdResponse(ctx context.Context, reader *bufio.Reader, done chan Summary, inFlight chan bool,
+	fileLock *sync.Mutex, fileWriter *bufio.Writer, pending *pendingKeys) {
 	var summary Summary //result summary
 	summary.LatArray = make([]int64, config.Benchmark.Throttle)
 	timer := time.NewTimer(time.Duration(config.Benchmark.T) * time.Second)
@@ -276,6 +503,7 @@ func getAbdResponse(reader *bufio.Reader, done chan Summary, inFlight chan bool)
 	// batchNum := reqNum/batchSize + 1
 	respMap := make(map[int64]int)
 	respChan := make(chan kvproto.Response, config.BufferSize)
+	cancelled := ctx.Done()
 
 	go func() {
 		for {
@@ -294,6 +522,13 @@ loop:
 		select {
 		case <-timer.C:
 			break loop
+		case <-cancelled:
+			log.Printf("Caught shutdown signal, draining in-flight acks for up to %s\n", shutdownDrainTimeout)
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer = time.NewTimer(shutdownDrainTimeout)
+			cancelled = nil
 		case resp := <-respChan:
 			tsNow := util.MakeTimestamp(0)
 			summary.AckNum += resp.Size
@@ -303,6 +538,19 @@ loop:
 				idx++
 			}
 			summary.TotalLat += (tsNow - resp.Ts) * int64(resp.Size)
+			live.record(summary.AckNum, summary.TotalLat, summary.LatArray[:idx])
+
+			txn := pending.take(resp.TID)
+			for i, k := range txn.keys {
+				v := kvproto.NIL
+				if i < len(resp.Vals) {
+					v = kvproto.Value(resp.Vals[i])
+				}
+				logOp(fileLock, fileWriter, OperationLog{
+					Type: RES, ClientId: txn.clientId, K: k, V: v, Ts: tsNow, TID: resp.TID,
+				})
+			}
+
 			if respMap[resp.TID] == batchSize {
 				//complte a btach
 				// log.Print("Complete a batch\n")
@@ -323,9 +571,9 @@ loop:
 	done <- summary
 }
 
-func epaxosClient(writer *bufio.Writer,
+func epaxosClient(ctx context.Context, writer *bufio.Writer,
 	kArray []int64, rArray []bool, txnNum int, inFlight chan int,
-	fileLock *sync.Mutex, fileWriter *bufio.Writer) {
+	fileLock *sync.Mutex, fileWriter *bufio.Writer, pending *pendingOps) {
 	time.Sleep(time.Duration(*serverId) * time.Millisecond)
 	batchSize := config.BatchSize
 	log.Printf("Total req num is %d\n", txnNum)
@@ -336,9 +584,16 @@ func epaxosClient(writer *bufio.Writer,
 	batchInterval := time.Duration(config.Benchmark.T * 1e9 / batchNum)
 	ticker := time.NewTicker(batchInterval)
 	i := 0
+	var nextCommandId int32
 	for i < txnNum {
+		select {
+		case <-ctx.Done():
+			log.Printf("Send loop cancelled at %d/%d\n", i, txnNum)
+			log.Printf("Out of loop3 %d, interval %d \n", i, batchInterval)
+			return
+		default:
+		}
 		args := genericsmrproto.Propose{
-			CommandId: 0,
 			Command: state.Command{
 				Op: state.PUT,
 				K:  0,
@@ -355,13 +610,27 @@ func epaxosClient(writer *bufio.Writer,
 		timeInt64 := util.MakeTimestamp(0)
 		for j := 0; j < bNum; j++ {
 			args.Timestamp = timeInt64
+			args.CommandId = nextCommandId
+			nextCommandId++
 			args.Command.K = state.Key(kArray[i])
+			kOp := kvproto.PUT
 			if rArray[i] {
 				args.Command.Op = state.GET
+				kOp = kvproto.GET
 			} else {
 				args.Command.Op = state.PUT
 				args.Command.V = state.Value(rand.Int63n(10000000))
+				kOp = kvproto.PUT
 			}
+
+			entry := OperationLog{
+				Type: INV, ClientId: *serverId, Op: kOp,
+				K: kvproto.Key(args.Command.K), V: kvproto.Value(args.Command.V), Ts: timeInt64,
+				TID: int64(args.CommandId),
+			}
+			logOp(fileLock, fileWriter, entry)
+			pending.put(args.CommandId, entry)
+
 			writer.WriteByte(genericsmrproto.PROPOSE)
 			args.Marshal(writer)
 			i++
@@ -374,15 +643,20 @@ func epaxosClient(writer *bufio.Writer,
 }
 
 func readFastEpaxosResponse(
+	ctx context.Context,
 	reader *bufio.Reader,
 	inFlight chan int,
-	done chan Summary) {
+	done chan Summary,
+	fileLock *sync.Mutex,
+	fileWriter *bufio.Writer,
+	pending *pendingOps) {
 	var summary Summary //result summary
 	summary.LatArray = make([]int64, config.Benchmark.Throttle)
 	benchTime := config.Benchmark.T
 	reply := new(genericsmrproto.ProposeReply)
 	timer := time.NewTimer(time.Duration(benchTime) * time.Second)
 	idx := 0
+	cancelled := ctx.Done()
 
 loop:
 	for {
@@ -400,7 +674,28 @@ loop:
 				summary.LatArray[idx] = lat
 				summary.TotalLat += lat
 				idx++
+				live.record(summary.AckNum, summary.TotalLat, summary.LatArray[:idx])
+
+				inv, ok := pending.take(reply.CommandId)
+				if !ok {
+					log.Printf("No matching INV for epaxos reply with CommandId %d\n", reply.CommandId)
+					continue
+				}
+				v := inv.V
+				if inv.Op == kvproto.GET {
+					v = kvproto.Value(reply.Value)
+				}
+				logOp(fileLock, fileWriter, OperationLog{
+					Type: RES, ClientId: inv.ClientId, K: inv.K, V: v, Ts: timeInt64, TID: inv.TID,
+				})
+			}
+		case <-cancelled:
+			log.Printf("Caught shutdown signal, draining in-flight acks for up to %s\n", shutdownDrainTimeout)
+			if !timer.Stop() {
+				<-timer.C
 			}
+			timer = time.NewTimer(shutdownDrainTimeout)
+			cancelled = nil
 		case <-timer.C:
 			break loop
 		}
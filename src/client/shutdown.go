@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// shutdownDrainTimeout bounds how long the response readers keep waiting
+// for in-flight requests to be acknowledged after a SIGINT/SIGTERM, so a
+// caught signal produces a prompt, bounded exit rather than hanging on a
+// replica that has stopped responding.
+const shutdownDrainTimeout = 2 * time.Second
+
+// liveStats mirrors the summary of whichever round is currently running
+// so a SIGUSR1 can report a snapshot. It is updated by the round's response
+// reader and read by the signal handler, hence the lock.
+type liveStats struct {
+	sync.Mutex
+	round    int
+	start    time.Time
+	ackNum   int
+	totalLat int64
+	lat      []int64
+}
+
+var live = &liveStats{}
+
+func (l *liveStats) reset(round int) {
+	l.Lock()
+	defer l.Unlock()
+	l.round = round
+	l.start = time.Now()
+	l.ackNum = 0
+	l.totalLat = 0
+	l.lat = nil
+}
+
+func (l *liveStats) record(ackNum int, totalLat int64, lat []int64) {
+	l.Lock()
+	defer l.Unlock()
+	l.ackNum = ackNum
+	l.totalLat = totalLat
+	l.lat = lat
+}
+
+func (l *liveStats) snapshot() (round int, stat Statistic, throughput float64) {
+	l.Lock()
+	defer l.Unlock()
+	elapsed := time.Since(l.start).Seconds()
+	if elapsed <= 0 {
+		elapsed = 1
+	}
+	stat = append(Statistic(nil), l.lat...)
+	throughput = float64(l.ackNum) / elapsed
+	return l.round, stat, throughput
+}
+
+// watchSignals is the client's signal loop: SIGUSR1 prints a live
+// snapshot of the in-progress round without disturbing it, while
+// SIGINT/SIGTERM cancels ctx so the running round's send loop stops and
+// its response reader drains for up to shutdownDrainTimeout before
+// returning a partial Summary.
+func watchSignals(sigCh chan os.Signal, cancel context.CancelFunc) {
+	for sig := range sigCh {
+		if sig == syscall.SIGUSR1 {
+			snapshotStats()
+			continue
+		}
+		log.Printf("Caught %v: cancelling the send loop and draining in-flight requests\n", sig)
+		cancel()
+		return
+	}
+}
+
+// snapshotStats reports the currently-running round's throughput and
+// latency percentiles to stderr so an operator can check on a
+// long-running benchmark without interrupting it.
+func snapshotStats() {
+	round, stat, throughput := live.snapshot()
+	fmt.Fprintf(os.Stderr, "[round %d live] throughput=%.2f req/s %s\n", round, throughput, stat)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Statistic is a set of latency samples, in milliseconds, with the
+// order-statistic helpers used for per-round and aggregate benchmark
+// reporting.
+type Statistic []int64
+
+func (s Statistic) sorted() Statistic {
+	out := make(Statistic, len(s))
+	copy(out, s)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func (s Statistic) Mean() float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, v := range s {
+		sum += v
+	}
+	return float64(sum) / float64(len(s))
+}
+
+// Percentile returns the p-th percentile (0 <= p <= 1) latency.
+func (s Statistic) Percentile(p float64) int64 {
+	if len(s) == 0 {
+		return 0
+	}
+	sorted := s.sorted()
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+func (s Statistic) Median() int64 { return s.Percentile(0.5) }
+func (s Statistic) P95() int64    { return s.Percentile(0.95) }
+func (s Statistic) P99() int64    { return s.Percentile(0.99) }
+
+func (s Statistic) Min() int64 {
+	if len(s) == 0 {
+		return 0
+	}
+	m := s[0]
+	for _, v := range s {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func (s Statistic) Max() int64 {
+	if len(s) == 0 {
+		return 0
+	}
+	m := s[0]
+	for _, v := range s {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func (s Statistic) StdDev() float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	mean := s.Mean()
+	var sq float64
+	for _, v := range s {
+		d := float64(v) - mean
+		sq += d * d
+	}
+	return math.Sqrt(sq / float64(len(s)))
+}
+
+func (s Statistic) String() string {
+	return fmt.Sprintf("n=%d mean=%.2fms p50=%dms p95=%dms p99=%dms min=%dms max=%dms stddev=%.2fms",
+		len(s), s.Mean(), s.Median(), s.P95(), s.P99(), s.Min(), s.Max(), s.StdDev())
+}
+
+// WriteFile dumps every latency sample, one per line, to name so a
+// killed or completed run always leaves a raw trace behind. It also
+// appends a one-line summary of this round to a companion CSV file
+// (name with its extension swapped for ".csv") so multi-round results
+// can be aggregated and plotted downstream.
+func (s Statistic) WriteFile(name string, round int, throughput float64) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, v := range s {
+		fmt.Fprintln(f, v)
+	}
+
+	csvPath := strings.TrimSuffix(name, filepath.Ext(name)) + ".csv"
+	return s.appendCSVRow(csvPath, round, throughput)
+}
+
+func (s Statistic) appendCSVRow(path string, round int, throughput float64) error {
+	newFile := false
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		newFile = true
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if newFile {
+		fmt.Fprintln(f, "round,n,mean_ms,p50_ms,p95_ms,p99_ms,min_ms,max_ms,stddev_ms,throughput_req_s")
+	}
+	_, err = fmt.Fprintf(f, "%d,%d,%.3f,%d,%d,%d,%d,%d,%.3f,%.3f\n",
+		round, len(s), s.Mean(), s.Median(), s.P95(), s.P99(), s.Min(), s.Max(), s.StdDev(), throughput)
+	return err
+}
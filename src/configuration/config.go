@@ -20,7 +20,7 @@ type Bconfig struct {
 	Concurrency          int     // number of simulated clients
 	Distribution         string  // distribution
 	LinearizabilityCheck bool    // run linearizability checker at the end of benchmark
-	// rounds       int    // repeat in many rounds sequentially
+	Rounds               int     // repeat the benchmark this many times back-to-back; overridden by -repeat
 
 	// conflict distribution
 	Conflicts int // percentage of conflicting keys
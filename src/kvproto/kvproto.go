@@ -31,7 +31,7 @@ type Version struct {
 // 	WrapAroundRange int32
 // }
 
-//minimum version
+// minimum version
 var DUMMYVS = Version{
 	ServerId: 0,
 	ThreadId: 0,
@@ -85,7 +85,7 @@ func (lhs *Version) Equal(rhs *Version) bool {
 	return lhs.Ts == rhs.Ts && lhs.R == rhs.R && lhs.ServerId == rhs.ServerId && lhs.ThreadId == rhs.ThreadId
 }
 
-//Copy what on right hand side to left hand side
+// Copy what on right hand side to left hand side
 func (lhs *Version) Copy(rhs *Version) {
 	lhs.ServerId = rhs.ServerId
 	lhs.ThreadId = rhs.ThreadId
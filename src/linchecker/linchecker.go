@@ -0,0 +1,249 @@
+// Package linchecker verifies that a benchmark run's recorded history of
+// client operations is linearizable against a single-register key/value
+// model, in the style of Wing & Gong's checker: histories are checked
+// independently per key by recursively picking a minimal pending
+// operation, applying it to the model, and backtracking on mismatch.
+package linchecker
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"kvproto"
+	"os"
+	"sort"
+)
+
+// Record is the on-disk format the benchmark clients append to the
+// linearizability log: one entry per invocation (Inv) or response (Res)
+// of a single key-level operation.
+type Record struct {
+	Type     uint8
+	ClientId int
+	Op       kvproto.Operation
+	K        kvproto.Key
+	V        kvproto.Value
+	Ts       int64
+	TID      int64
+}
+
+const (
+	Inv = uint8(0)
+	Res = uint8(1)
+)
+
+// op is a completed (invocation, response) pair against a single key.
+type op struct {
+	clientId int
+	kind     kvproto.Operation
+	value    kvproto.Value
+	invTs    int64
+	resTs    int64
+}
+
+// Result is the outcome of checking a single key's history.
+type Result struct {
+	Key            kvproto.Key
+	Linearizable   bool
+	Ops            int
+	Counterexample string
+}
+
+// maxHistory bounds the per-key op count the bitmask memoization can
+// track; histories longer than this are reported unchecked rather than
+// silently truncated.
+const maxHistory = 64
+
+// Check reads the operation log written by the benchmark clients at path
+// and verifies, independently for each key, that its history admits a
+// linearization against a single register (PUT sets the value, GET must
+// return the most recently linearized value).
+func Check(path string) (map[kvproto.Key]Result, error) {
+	histories, err := readHistories(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[kvproto.Key]Result, len(histories))
+	for k, ops := range histories {
+		results[k] = checkKey(k, ops)
+	}
+	return results, nil
+}
+
+// readHistories replays the log and pairs each invocation with its
+// response. Clients may have several transactions in flight at once
+// (see abdClient's pendingKeys in the client package), so a hot key can
+// legitimately see its responses arrive out of invocation order; FIFO
+// pairing within a (client, key) bucket would then attach the wrong
+// response to the wrong invocation. TID disambiguates: it's the
+// transaction id the client already tags every INV/RES with, so pending
+// invocations are bucketed by (client, key, TID) and matched against the
+// response carrying the same TID rather than assumed file order.
+func readHistories(path string) (map[kvproto.Key][]op, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type pendKey struct {
+		clientId int
+		k        kvproto.Key
+		tid      int64
+	}
+	pending := make(map[pendKey][]Record)
+	histories := make(map[kvproto.Key][]op)
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		var r Record
+		if err := dec.Decode(&r); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("linchecker: decoding %s: %w", path, err)
+		}
+
+		pk := pendKey{r.ClientId, r.K, r.TID}
+		if r.Type == Inv {
+			pending[pk] = append(pending[pk], r)
+			continue
+		}
+
+		queue := pending[pk]
+		if len(queue) == 0 {
+			return nil, fmt.Errorf("linchecker: RES for key %d (client %d, tid %d) with no matching INV", r.K, r.ClientId, r.TID)
+		}
+		inv := queue[0]
+		pending[pk] = queue[1:]
+
+		value := r.V
+		if inv.Op == kvproto.PUT {
+			value = inv.V
+		}
+		histories[r.K] = append(histories[r.K], op{
+			clientId: r.ClientId,
+			kind:     inv.Op,
+			value:    value,
+			invTs:    inv.Ts,
+			resTs:    r.Ts,
+		})
+	}
+
+	for k := range histories {
+		sort.Slice(histories[k], func(i, j int) bool {
+			return histories[k][i].invTs < histories[k][j].invTs
+		})
+	}
+	return histories, nil
+}
+
+// checkKey searches for a linearization of a single key's history. The
+// register starts at kvproto.NIL; PUT sets the register, GET must observe
+// it. At each step only a "minimal" pending op may be tried next: one
+// whose invocation precedes every other pending op's response, since any
+// op that already finished must be linearized first. The search
+// memoizes on (set of completed ops, register value) to avoid
+// re-exploring the same state.
+func checkKey(k kvproto.Key, ops []op) Result {
+	if len(ops) > maxHistory {
+		return Result{Key: k, Ops: len(ops), Linearizable: false,
+			Counterexample: fmt.Sprintf("history of %d ops exceeds checker limit of %d, not checked", len(ops), maxHistory)}
+	}
+
+	var all uint64
+	for i := range ops {
+		all |= uint64(1) << uint(i)
+	}
+
+	type memoState struct {
+		pending uint64
+		reg     kvproto.Value
+	}
+	failed := make(map[memoState]bool) // memo: (pending set, register) -> known dead end
+	var trail []int
+	var best []int
+
+	var search func(pending uint64, reg kvproto.Value) bool
+	search = func(pending uint64, reg kvproto.Value) bool {
+		if pending == 0 {
+			return true
+		}
+		memoKey := memoState{pending, reg}
+		if failed[memoKey] {
+			return false
+		}
+
+		for i, o := range ops {
+			bit := uint64(1) << uint(i)
+			if pending&bit == 0 || !isMinimal(ops, pending, i) {
+				continue
+			}
+
+			next := reg
+			if o.kind == kvproto.PUT {
+				next = o.value
+			} else if o.value != reg {
+				continue
+			}
+
+			trail = append(trail, i)
+			if len(trail) > len(best) {
+				best = append([]int(nil), trail...)
+			}
+			if search(pending&^bit, next) {
+				return true
+			}
+			trail = trail[:len(trail)-1]
+		}
+
+		failed[memoKey] = true
+		return false
+	}
+
+	if search(all, kvproto.NIL) {
+		return Result{Key: k, Ops: len(ops), Linearizable: true}
+	}
+	return Result{Key: k, Ops: len(ops), Linearizable: false, Counterexample: counterexample(ops, best)}
+}
+
+// isMinimal reports whether ops[i] may legally be linearized next: no
+// other still-pending operation's response precedes its invocation.
+func isMinimal(ops []op, pending uint64, i int) bool {
+	for j, o := range ops {
+		if j == i {
+			continue
+		}
+		if pending&(uint64(1)<<uint(j)) == 0 {
+			continue
+		}
+		if o.resTs <= ops[i].invTs {
+			return false
+		}
+	}
+	return true
+}
+
+// counterexample serializes the deepest partial linearization the search
+// found before getting stuck, followed by a "<stuck>" marker.
+func counterexample(ops []op, trail []int) string {
+	s := ""
+	for _, i := range trail {
+		o := ops[i]
+		s += fmt.Sprintf("client %d %s(%v) [%d,%d] -> ", o.clientId, opName(o.kind), o.value, o.invTs, o.resTs)
+	}
+	return s + "<stuck>"
+}
+
+func opName(o kvproto.Operation) string {
+	switch o {
+	case kvproto.GET:
+		return "GET"
+	case kvproto.PUT:
+		return "PUT"
+	default:
+		return "NONE"
+	}
+}
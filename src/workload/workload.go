@@ -0,0 +1,202 @@
+// Package workload generates the key sequence a benchmark client drives
+// against the replicated store. Each supported access pattern is a
+// KeyGenerator so main can pick one by name (configuration.Bconfig's
+// Distribution field) without the call sites caring which distribution
+// is actually in use.
+package workload
+
+import (
+	"configuration"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// KeyGenerator produces the next key a client should operate on. A
+// generator is only ever driven by the single client goroutine that
+// owns it, so implementations need not be safe for concurrent use.
+type KeyGenerator interface {
+	Next() int64
+}
+
+// New builds the KeyGenerator named by distribution. base is the
+// client's private key-range offset (the "-sr" flag), used by the
+// legacy conflict-percent model. Unrecognized or empty names (i.e. every
+// config that predates this package) fall back to the legacy conflict
+// model for back-compat, with a log line announcing the fallback so the
+// choice of generator is never silent.
+func New(distribution string, b configuration.Bconfig, base int, seed *rand.Rand) (KeyGenerator, error) {
+	switch distribution {
+	case "uniform":
+		return NewUniform(b.Min, b.K, seed), nil
+	case "normal":
+		return NewNormal(b.Mu, b.Sigma, b.Min, b.K, b.Move, b.Speed, seed), nil
+	case "exponential":
+		return NewExponential(b.Lambda, b.Min, b.K, seed), nil
+	case "zipfan":
+		return NewZipf(b.ZipfianS, b.ZipfianV, b.K, b.Min, seed)
+	case "zipf-mandelbrot":
+		return NewZipfMandelbrot(b.ZipfianV, b.ZipfianS, b.K, b.Min, seed), nil
+	case "conflict":
+		return NewConflict(base, b.Conflicts, seed), nil
+	default:
+		log.Printf("workload: distribution %q not recognized, defaulting to the legacy conflict model\n", distribution)
+		return NewConflict(base, b.Conflicts, seed), nil
+	}
+}
+
+// clamp folds v into the key range [min, min+k).
+func clamp(v int64, min, k int) int64 {
+	if k <= 0 {
+		return int64(min)
+	}
+	v %= int64(k)
+	if v < 0 {
+		v += int64(k)
+	}
+	return int64(min) + v
+}
+
+// Uniform picks keys uniformly at random from [Min, Min+K).
+type Uniform struct {
+	min, k int
+	r      *rand.Rand
+}
+
+func NewUniform(min, k int, seed *rand.Rand) *Uniform {
+	if k <= 0 {
+		k = 1
+	}
+	return &Uniform{min: min, k: k, r: seed}
+}
+
+func (u *Uniform) Next() int64 {
+	return int64(u.min) + u.r.Int63n(int64(u.k))
+}
+
+// Conflict reproduces the benchmark's original hot-key model: with
+// probability Conflicts percent it returns the single contended key 42,
+// otherwise a key private to this client that never repeats.
+type Conflict struct {
+	base, conflicts, n int
+	r                  *rand.Rand
+}
+
+func NewConflict(base, conflicts int, seed *rand.Rand) *Conflict {
+	return &Conflict{base: base, conflicts: conflicts, r: seed}
+}
+
+func (c *Conflict) Next() int64 {
+	k := int64(42)
+	if c.r.Intn(100) >= c.conflicts {
+		k = int64(c.base + 43 + c.n)
+	}
+	c.n++
+	return k
+}
+
+// Normal picks keys from a normal distribution with mean Mu and
+// standard deviation Sigma, clamped into [Min, Min+K). If Move is set,
+// Mu advances by one key every Speed milliseconds so the hot range
+// drifts over the course of the run.
+type Normal struct {
+	mu, sigma   float64
+	min, k      int
+	move        bool
+	speedMillis int
+	start       time.Time
+	r           *rand.Rand
+}
+
+func NewNormal(mu, sigma float64, min, k int, move bool, speedMillis int, seed *rand.Rand) *Normal {
+	return &Normal{mu: mu, sigma: sigma, min: min, k: k, move: move, speedMillis: speedMillis, start: time.Now(), r: seed}
+}
+
+func (n *Normal) Next() int64 {
+	mu := n.mu
+	if n.move && n.speedMillis > 0 {
+		mu += float64(time.Since(n.start).Milliseconds() / int64(n.speedMillis))
+	}
+	v := int64(math.Round(n.r.NormFloat64()*n.sigma + mu))
+	return clamp(v, n.min, n.k)
+}
+
+// Exponential picks keys whose offset from Min is drawn from an
+// exponential distribution with rate Lambda, clamped into [Min, Min+K).
+type Exponential struct {
+	lambda float64
+	min, k int
+	r      *rand.Rand
+}
+
+func NewExponential(lambda float64, min, k int, seed *rand.Rand) *Exponential {
+	return &Exponential{lambda: lambda, min: min, k: k, r: seed}
+}
+
+func (e *Exponential) Next() int64 {
+	v := int64(e.r.ExpFloat64() / e.lambda)
+	return clamp(v, e.min, e.k)
+}
+
+// Zipf picks keys from a classic Zipf distribution, parameterized as in
+// math/rand: s controls skew (s > 1) and v shifts the generalized
+// harmonic series used to weight low ranks.
+type Zipf struct {
+	min int
+	z   *rand.Zipf
+}
+
+// NewZipf validates its parameters against rand.NewZipf's documented
+// contract (s > 1, v >= 1) and k > 0 before constructing the generator:
+// rand.NewZipf silently returns nil outside that range, which would
+// otherwise panic on the first Next() call instead of failing at
+// startup where the bad config can actually be diagnosed.
+func NewZipf(s, v float64, k, min int, seed *rand.Rand) (*Zipf, error) {
+	if s <= 1 || v < 1 || k <= 0 {
+		return nil, fmt.Errorf("workload: invalid zipf parameters (s=%v must be >1, v=%v must be >=1, k=%v must be >0)", s, v, k)
+	}
+	imax := uint64(k - 1)
+	return &Zipf{min: min, z: rand.NewZipf(seed, s, v, imax)}, nil
+}
+
+func (z *Zipf) Next() int64 {
+	return int64(z.min) + int64(z.z.Uint64())
+}
+
+// ZipfMandelbrot generalizes Zipf with a plateau parameter q that
+// spreads weight away from the single hottest key. Its CDF has no
+// closed form to invert, so Next binary-searches a precomputed table of
+// cumulative weights built from the generalized harmonic number
+// sum_{i=0}^{N-1} 1/(i+1+q)^s.
+type ZipfMandelbrot struct {
+	min int
+	cdf []float64
+	r   *rand.Rand
+}
+
+func NewZipfMandelbrot(q, s float64, n, min int, seed *rand.Rand) *ZipfMandelbrot {
+	if n <= 0 {
+		n = 1
+	}
+	cdf := make([]float64, n)
+	var total float64
+	for i := 0; i < n; i++ {
+		total += 1 / math.Pow(float64(i+1)+q, s)
+		cdf[i] = total
+	}
+	for i := range cdf {
+		cdf[i] /= total
+	}
+	return &ZipfMandelbrot{min: min, cdf: cdf, r: seed}
+}
+
+func (z *ZipfMandelbrot) Next() int64 {
+	idx := sort.SearchFloat64s(z.cdf, z.r.Float64())
+	if idx >= len(z.cdf) {
+		idx = len(z.cdf) - 1
+	}
+	return int64(z.min) + int64(idx)
+}